@@ -1,47 +1,98 @@
 package appcatalog
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/Travix-International/appix/appixLogger"
+	"github.com/Travix-International/appix/cache"
 )
 
-// UploadToFrontend uploads the app package to the frontend for bundling.
-func UploadToFrontend(uploadURI string, zapFile string, appName string, sessionID string, verbose bool) (pollURI string, err error) {
-	files := map[string]string{
-		"file": zapFile,
+// cacheEnabled controls whether UploadToFrontend skips re-uploading a bundle whose digest
+// matches the last one uploaded to the same target. See SetCacheEnabled.
+var cacheEnabled = true
+
+// SetCacheEnabled toggles the local upload cache under ~/.appix/cache/. Pass false (e.g.
+// behind a --no-cache flag) to always upload, even when the zap file hasn't changed since the
+// last successful upload to this target.
+func SetCacheEnabled(enabled bool) {
+	cacheEnabled = enabled
+}
+
+// UploadToFrontend streams the app package to the frontend for bundling. The zap file is
+// streamed straight off disk through an io.Pipe rather than buffered in memory, and, when
+// verbose is set, its progress is reported to logger. The upload is aborted as soon as ctx is cancelled, so a
+// watcher can abort an in-flight upload when a newer change arrives instead of waiting for it
+// to finish first.
+//
+// If the zap file's digest matches the one recorded for the last successful upload to
+// uploadURI, the upload is skipped entirely and the cached poll URI is returned - useful in
+// watch mode, where editors frequently rewrite a file without changing its content.
+func UploadToFrontend(ctx context.Context, uploadURI string, zapFile string, appName string, sessionID string, timeout time.Duration, verbose bool, logger *appixLogger.Logger) (pollURI string, err error) {
+	fileInfo, err := os.Stat(zapFile)
+
+	if err != nil {
+		return "", err
 	}
 
-	params := map[string]string{
-		"name": appName,
+	digest, err := cache.Digest(zapFile)
+
+	if err != nil {
+		return "", err
 	}
 
-	if verbose {
-		log.Println("Uploading the app to the Express frontend: " + uploadURI)
-		log.Println("Creating multi-file upload request")
+	if cacheEnabled {
+		if cachedDigest, cachedPollURI, found, err := cache.Lookup(appName, uploadURI); err == nil && found && cachedDigest == digest {
+			log.Println("The app hasn't changed since the last push, skipping the upload.")
+			return cachedPollURI, nil
+		}
 	}
 
-	request, err := CreateMultiFileUploadRequest(uploadURI, files, params, verbose)
+	header, closing, contentType, err := multipartEnvelope(appName, filepath.Base(zapFile))
 
 	if err != nil {
-		log.Println("Creating the HTTP request failed.")
 		return "", err
 	}
 
+	pipeReader, pipeWriter := io.Pipe()
+
+	request, err := http.NewRequest(http.MethodPost, uploadURI, pipeReader)
+
+	if err != nil {
+		return "", err
+	}
+
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", contentType)
+	request.ContentLength = int64(header.Len()) + fileInfo.Size() + int64(closing.Len())
+
+	go streamUpload(pipeWriter, header, closing, zapFile, fileInfo.Size(), logger, verbose)
+
 	if verbose {
-		log.Println("Multi-file upload request created, proceeding to call front-end")
+		log.Println("Uploading the app to the Express frontend: " + uploadURI)
 	}
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: timeout}
 	response, err := client.Do(request)
+
 	if err != nil {
 		log.Println("Call to the Express frontend failed.")
 		return "", err
 	}
 
+	defer response.Body.Close()
+
 	if verbose {
 		logServerResponse(response)
 	}
@@ -78,7 +129,60 @@ func UploadToFrontend(uploadURI string, zapFile string, appName string, sessionI
 		return "", fmt.Errorf("Uploading failed, the app catalog did not return a valid response")
 	}
 
+	if err := cache.Record(appName, uploadURI, digest, progressUri); err != nil && verbose {
+		log.Println("Failed to record the upload cache entry:", err)
+	}
+
 	log.Println("The app has been uploaded to the frontend successfully.")
 
 	return progressUri, nil
 }
+
+// multipartEnvelope builds the "name" field and the "file" part header up front, and the
+// closing boundary that follows the file content, so the total request size - and therefore
+// an accurate Content-Length - is known before the (potentially large) zap file is read.
+func multipartEnvelope(appName string, fileName string) (header *bytes.Buffer, closing *bytes.Buffer, contentType string, err error) {
+	header = &bytes.Buffer{}
+	multipartWriter := multipart.NewWriter(header)
+
+	if err := multipartWriter.WriteField("name", appName); err != nil {
+		return nil, nil, "", err
+	}
+
+	if _, err := multipartWriter.CreateFormFile("file", fileName); err != nil {
+		return nil, nil, "", err
+	}
+
+	closing = bytes.NewBufferString(fmt.Sprintf("\r\n--%s--\r\n", multipartWriter.Boundary()))
+
+	return header, closing, multipartWriter.FormDataContentType(), nil
+}
+
+// streamUpload writes header, the zap file's content (reporting its progress as it goes),
+// and closing into pipeWriter, in that order, then always closes pipeWriter - with an error
+// if anything failed, so the blocked client.Do(request) in UploadToFrontend unblocks either
+// way.
+func streamUpload(pipeWriter *io.PipeWriter, header *bytes.Buffer, closing *bytes.Buffer, zapFile string, size int64, logger *appixLogger.Logger, verbose bool) {
+	err := func() error {
+		if _, err := pipeWriter.Write(header.Bytes()); err != nil {
+			return err
+		}
+
+		file, err := os.Open(zapFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		progress := newProgressReader(file, size, logger, verbose)
+
+		if _, err := io.Copy(pipeWriter, progress); err != nil {
+			return err
+		}
+
+		_, err = pipeWriter.Write(closing.Bytes())
+		return err
+	}()
+
+	pipeWriter.CloseWithError(err)
+}
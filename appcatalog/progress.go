@@ -0,0 +1,63 @@
+package appcatalog
+
+import (
+	"io"
+	"time"
+
+	"github.com/Travix-International/appix/appixLogger"
+)
+
+// reportInterval throttles how often progress is logged, so a fast local upload doesn't
+// flood the terminal with one line per chunk.
+const reportInterval = 250 * time.Millisecond
+
+// progressReader wraps a reader, reporting bytes transferred, the total size, throughput and
+// an ETA to logger as it's read (only when verbose is set), similar to Docker's progress.NewProgressReader.
+type progressReader struct {
+	reader  io.Reader
+	total   int64
+	read    int64
+	started time.Time
+	logger  *appixLogger.Logger
+	verbose bool
+
+	lastReport time.Time
+}
+
+func newProgressReader(reader io.Reader, total int64, logger *appixLogger.Logger, verbose bool) *progressReader {
+	return &progressReader{
+		reader:  reader,
+		total:   total,
+		started: time.Now(),
+		logger:  logger,
+		verbose: verbose,
+	}
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	p.read += int64(n)
+
+	if p.verbose && (time.Since(p.lastReport) >= reportInterval || err == io.EOF) {
+		p.report()
+		p.lastReport = time.Now()
+	}
+
+	return n, err
+}
+
+func (p *progressReader) report() {
+	elapsed := time.Since(p.started).Seconds()
+
+	var throughput float64
+	if elapsed > 0 {
+		throughput = float64(p.read) / elapsed
+	}
+
+	var eta time.Duration
+	if throughput > 0 {
+		eta = time.Duration(float64(p.total-p.read)/throughput) * time.Second
+	}
+
+	p.logger.Printf("Uploading: %d/%d bytes (%.1f KB/s, ETA %s)", p.read, p.total, throughput/1024, eta.Round(time.Second))
+}
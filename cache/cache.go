@@ -0,0 +1,135 @@
+// Package cache records the digest of the last zap bundle successfully uploaded per app and
+// frontend, under ~/.appix/cache/, so appix can skip re-uploading a bundle that hasn't
+// actually changed.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// entry is what's recorded for one app+target: the digest of the last bundle uploaded, and
+// the poll URI the frontend returned for it.
+type entry struct {
+	Digest  string
+	PollURI string
+}
+
+// Dir returns ~/.appix/cache, creating it if it doesn't exist yet.
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(home, ".appix", "cache")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// Digest returns the sha256 digest of the file at path, hex-encoded.
+func Digest(path string) (string, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	hasher := sha256.New()
+
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// entryPath returns the cache file for the given appName+target pair. target disambiguates
+// where the bundle was uploaded to (e.g. the upload URI), so the same app pushed to two
+// different frontends is cached independently.
+func entryPath(appName string, target string) (string, error) {
+	dir, err := Dir()
+
+	if err != nil {
+		return "", err
+	}
+
+	name := sha256.Sum256([]byte(appName + "@" + target))
+
+	return filepath.Join(dir, hex.EncodeToString(name[:])+".entry"), nil
+}
+
+// Lookup returns the entry recorded for appName+target's last successful upload. found is
+// false if there's no record yet.
+func Lookup(appName string, target string) (digest string, pollURI string, found bool, err error) {
+	path, err := entryPath(appName, target)
+
+	if err != nil {
+		return "", "", false, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return "", "", false, nil
+	}
+
+	if err != nil {
+		return "", "", false, err
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+
+	if len(fields) != 2 {
+		return "", "", false, nil
+	}
+
+	return fields[0], fields[1], true, nil
+}
+
+// Record stores digest (and the pollURI the frontend returned for it) as the last uploaded
+// entry for appName+target.
+func Record(appName string, target string, digest string, pollURI string) error {
+	path, err := entryPath(appName, target)
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, []byte(digest+"\n"+pollURI), 0644)
+}
+
+// Clean removes every recorded entry, forcing the next push of every app to go through.
+func Clean() error {
+	dir, err := Dir()
+
+	if err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withTempHome points Dir() at a fresh, empty HOME for the duration of the test, so entries
+// written by one test can't be observed by another.
+func withTempHome(t *testing.T) {
+	t.Helper()
+
+	home, err := ioutil.TempDir("", "appix-cache-test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+
+	os.Setenv("HOME", home)
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", oldHome)
+		os.RemoveAll(home)
+	})
+}
+
+func TestLookupMissing(t *testing.T) {
+	withTempHome(t)
+
+	_, _, found, err := Lookup("my-app", "https://example.test/upload")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found {
+		t.Fatal("Lookup found an entry that was never recorded")
+	}
+}
+
+func TestRecordThenLookupRoundTrip(t *testing.T) {
+	withTempHome(t)
+
+	if err := Record("my-app", "https://example.test/upload", "deadbeef", "https://example.test/poll/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, pollURI, found, err := Lookup("my-app", "https://example.test/upload")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !found {
+		t.Fatal("Lookup did not find the entry Record just wrote")
+	}
+
+	if digest != "deadbeef" {
+		t.Errorf("digest = %q, want %q", digest, "deadbeef")
+	}
+
+	if pollURI != "https://example.test/poll/1" {
+		t.Errorf("pollURI = %q, want %q", pollURI, "https://example.test/poll/1")
+	}
+}
+
+func TestRecordOverwritesPreviousEntry(t *testing.T) {
+	withTempHome(t)
+
+	if err := Record("my-app", "https://example.test/upload", "first", "https://example.test/poll/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Record("my-app", "https://example.test/upload", "second", "https://example.test/poll/2"); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, pollURI, found, err := Lookup("my-app", "https://example.test/upload")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !found || digest != "second" || pollURI != "https://example.test/poll/2" {
+		t.Fatalf("got (%q, %q, %v), want (\"second\", \"https://example.test/poll/2\", true)", digest, pollURI, found)
+	}
+}
+
+func TestLookupIsolatedByTarget(t *testing.T) {
+	withTempHome(t)
+
+	if err := Record("my-app", "https://one.test/upload", "digest-one", "https://one.test/poll"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, found, err := Lookup("my-app", "https://two.test/upload")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found {
+		t.Fatal("Lookup found an entry recorded for a different target")
+	}
+}
+
+func TestDigest(t *testing.T) {
+	withTempHome(t)
+
+	dir, err := ioutil.TempDir("", "appix-cache-digest-test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bundle.zap")
+
+	if err := ioutil.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := Digest(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantDigest = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	if digest != wantDigest {
+		t.Errorf("Digest(%q) = %q, want %q", path, digest, wantDigest)
+	}
+}
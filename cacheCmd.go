@@ -0,0 +1,24 @@
+package appix
+
+import (
+	"log"
+
+	"github.com/Travix-International/appix/cache"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// RegisterCache registers the 'cache' command and its 'clean' subcommand.
+func RegisterCache(app *kingpin.Application) {
+	cacheCommand := app.Command("cache", "Manage appix's local upload cache.")
+
+	cacheCommand.Command("clean", "Remove every cached upload digest, forcing the next push of every app to go through.").
+		Action(func(parseContext *kingpin.ParseContext) error {
+			if err := cache.Clean(); err != nil {
+				log.Fatal(err)
+			}
+
+			log.Println("Cache cleaned.")
+
+			return nil
+		})
+}
@@ -0,0 +1,50 @@
+package appix
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Travix-International/appix/watcher"
+)
+
+// eventClassNames maps the names accepted by --events to their watcher.Op, in the order
+// they're documented.
+var eventClassNames = []struct {
+	name string
+	op   watcher.Op
+}{
+	{"create", watcher.Create},
+	{"write", watcher.Write},
+	{"rename", watcher.Rename},
+	{"remove", watcher.Remove},
+}
+
+// parseEventClasses turns a comma-separated --events value into the watcher.Op mask it
+// selects, defaulting to every class when raw is empty.
+func parseEventClasses(raw string) (watcher.Op, error) {
+	if strings.TrimSpace(raw) == "" {
+		return watcher.All, nil
+	}
+
+	var ops watcher.Op
+
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		found := false
+
+		for _, class := range eventClassNames {
+			if class.name == name {
+				ops |= class.op
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return 0, fmt.Errorf("unknown event class %q, expected one of create, write, rename, remove", name)
+		}
+	}
+
+	return ops, nil
+}
@@ -0,0 +1,117 @@
+package appix
+
+import (
+	"bufio"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ignoreFileName is the per-app ignore file, checked first.
+const ignoreFileName = ".appixignore"
+
+// ignoreDirName holds shared ignore fragments that apply on top of ignoreFileName, so teams
+// can ship a common set of patterns without every app editing the same monolithic file.
+const ignoreDirName = ".appix/ignore.d"
+
+// IgnoreFilePath reports whether relPath should be excluded from the zap/push for the app
+// rooted at appRoot, based on appRoot's .appixignore and every *.ignore file under
+// .appix/ignore.d, applied in that order.
+func IgnoreFilePath(appRoot string, relPath string) bool {
+	patterns, err := loadIgnorePatterns(appRoot)
+
+	if err != nil {
+		log.Printf("Error reading ignore patterns for %s: %v\n", appRoot, err)
+		return false
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, relPath); matched {
+			return true
+		}
+
+		if matched, _ := filepath.Match(pattern, filepath.Base(relPath)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadIgnorePatterns gathers every pattern that applies to appRoot: first the app's own
+// .appixignore, then the *.ignore files under .appix/ignore.d in name order.
+func loadIgnorePatterns(appRoot string) ([]string, error) {
+	patterns, err := readPatternFile(filepath.Join(appRoot, ignoreFileName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	ignoreDir := filepath.Join(appRoot, ignoreDirName)
+
+	entries, err := ioutil.ReadDir(ignoreDir)
+
+	if os.IsNotExist(err) {
+		return patterns, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var fragmentNames []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".ignore") {
+			fragmentNames = append(fragmentNames, entry.Name())
+		}
+	}
+
+	sort.Strings(fragmentNames)
+
+	for _, name := range fragmentNames {
+		fragmentPatterns, err := readPatternFile(filepath.Join(ignoreDir, name))
+
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, fragmentPatterns...)
+	}
+
+	return patterns, nil
+}
+
+// readPatternFile reads one pattern per non-empty, non-comment line. A missing file is not
+// an error, it simply contributes no patterns.
+func readPatternFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return patterns, scanner.Err()
+}
@@ -0,0 +1,99 @@
+package appix
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadIgnorePatternsOrder(t *testing.T) {
+	appRoot, err := ioutil.TempDir("", "appix-ignore-test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(appRoot)
+
+	writeFile(t, filepath.Join(appRoot, ignoreFileName), "# comment\nfrom-appixignore\n")
+	writeFile(t, filepath.Join(appRoot, ignoreDirName, "b.ignore"), "from-b\n")
+	writeFile(t, filepath.Join(appRoot, ignoreDirName, "a.ignore"), "from-a\n")
+
+	patterns, err := loadIgnorePatterns(appRoot)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"from-appixignore", "from-a", "from-b"}
+
+	if len(patterns) != len(want) {
+		t.Fatalf("patterns = %v, want %v", patterns, want)
+	}
+
+	for i, pattern := range patterns {
+		if pattern != want[i] {
+			t.Errorf("patterns[%d] = %q, want %q", i, pattern, want[i])
+		}
+	}
+}
+
+func TestLoadIgnorePatternsNoIgnoreDir(t *testing.T) {
+	appRoot, err := ioutil.TempDir("", "appix-ignore-test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(appRoot)
+
+	writeFile(t, filepath.Join(appRoot, ignoreFileName), "*.log\n")
+
+	patterns, err := loadIgnorePatterns(appRoot)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patterns) != 1 || patterns[0] != "*.log" {
+		t.Fatalf("patterns = %v, want [*.log]", patterns)
+	}
+}
+
+func TestIgnoreFilePath(t *testing.T) {
+	appRoot, err := ioutil.TempDir("", "appix-ignore-test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(appRoot)
+
+	writeFile(t, filepath.Join(appRoot, ignoreFileName), "*.log\nnode_modules/*\n")
+
+	cases := map[string]bool{
+		"app.log":               true,
+		"nested/debug.log":      true,
+		"node_modules/some-pkg": true,
+		"src/main.go":           false,
+	}
+
+	for relPath, want := range cases {
+		if got := IgnoreFilePath(appRoot, relPath); got != want {
+			t.Errorf("IgnoreFilePath(%q) = %v, want %v", relPath, got, want)
+		}
+	}
+}
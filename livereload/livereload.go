@@ -0,0 +1,170 @@
+// Package livereload implements a small LiveReload-protocol (http://livereload.com/protocols/)
+// server, so browsers viewing the app being pushed can refresh themselves without the user
+// having to install a browser extension.
+package livereload
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// officialProtocol is the protocol version we speak, as listed at
+// http://livereload.com/protocols/official-7/.
+const officialProtocol = "http://livereload.com/protocols/official-7"
+
+var (
+	port int = 35729
+
+	mu      sync.Mutex
+	clients = map[*websocket.Conn]bool{}
+)
+
+// SetPort overrides the port the LiveReload server listens on. It must be called before
+// StartServer.
+func SetPort(p int) {
+	port = p
+}
+
+// StartServer starts the LiveReload websocket server in the background. It serves the
+// injected snippet at /livereload.js and accepts connections at /livereload.
+func StartServer() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livereload.js", serveSnippet)
+	mux.Handle("/livereload", websocket.Handler(handleConn))
+
+	go func() {
+		addr := fmt.Sprintf(":%d", port)
+
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Println("LiveReload server failed to start:", err)
+		}
+	}()
+}
+
+// SendReload triggers a full page reload on every connected browser.
+func SendReload() {
+	broadcast(reloadMessage{Command: "reload", Path: "*"})
+}
+
+// SendReloadForPaths inspects changedPaths and sends the narrowest LiveReload command that
+// covers them: a CSS-only refresh when every changed path is a stylesheet, an image
+// cache-bust when every changed path is an image, and a full reload otherwise (in
+// particular for any JS or HTML change).
+func SendReloadForPaths(changedPaths []string) {
+	if len(changedPaths) == 0 {
+		SendReload()
+		return
+	}
+
+	allCSS := true
+	allImages := true
+
+	for _, changedPath := range changedPaths {
+		ext := strings.ToLower(filepath.Ext(changedPath))
+
+		if ext != ".css" {
+			allCSS = false
+		}
+
+		if !isImageExt(ext) {
+			allImages = false
+		}
+	}
+
+	switch {
+	case allCSS:
+		for _, changedPath := range changedPaths {
+			broadcast(reloadMessage{Command: "reload", Path: changedPath, LiveCSS: true})
+		}
+	case allImages:
+		for _, changedPath := range changedPaths {
+			broadcast(reloadMessage{Command: "reload", Path: changedPath, LiveImg: true})
+		}
+	default:
+		SendReload()
+	}
+}
+
+func isImageExt(ext string) bool {
+	switch ext {
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg":
+		return true
+	default:
+		return false
+	}
+}
+
+// helloMessage is the server's response to the client's initial "hello" handshake.
+type helloMessage struct {
+	Command    string   `json:"command"`
+	Protocols  []string `json:"protocols"`
+	ServerName string   `json:"serverName"`
+}
+
+// reloadMessage asks the client to refresh a path. LiveCSS and LiveImg let the client
+// swap the asset in place instead of reloading the whole page.
+type reloadMessage struct {
+	Command string `json:"command"`
+	Path    string `json:"path"`
+	LiveCSS bool   `json:"liveCSS"`
+	LiveImg bool   `json:"liveImg"`
+}
+
+func handleConn(ws *websocket.Conn) {
+	mu.Lock()
+	clients[ws] = true
+	mu.Unlock()
+
+	defer func() {
+		mu.Lock()
+		delete(clients, ws)
+		mu.Unlock()
+		ws.Close()
+	}()
+
+	for {
+		var msg map[string]interface{}
+
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return
+		}
+
+		if msg["command"] == "hello" {
+			hello := helloMessage{
+				Command:    "hello",
+				Protocols:  []string{officialProtocol},
+				ServerName: "appix",
+			}
+
+			if err := websocket.JSON.Send(ws, hello); err != nil {
+				log.Println("LiveReload: failed to send hello response:", err)
+				return
+			}
+		}
+
+		// "info" messages (the client reporting its URL and installed plugins) and anything
+		// else we don't recognise are simply ignored, per the protocol.
+	}
+}
+
+func serveSnippet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	fmt.Fprintf(w, "window.appixLiveReloadPort = %d;\n%s", port, injectedSnippet)
+}
+
+func broadcast(msg interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for ws := range clients {
+		if err := websocket.JSON.Send(ws, msg); err != nil {
+			log.Println("LiveReload: failed to send to client:", err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package livereload
+
+// injectedSnippet is served at /livereload.js. Apps being watched include it with a single
+// <script> tag, and it connects back to this server over a websocket to receive reload
+// commands.
+const injectedSnippet = `
+(function () {
+	var protocol = window.location.protocol === "https:" ? "wss:" : "ws:";
+	var socket = new WebSocket(protocol + "//" + window.location.hostname + ":" + window.appixLiveReloadPort + "/livereload");
+
+	socket.onopen = function () {
+		socket.send(JSON.stringify({ command: "hello", protocols: ["http://livereload.com/protocols/official-7"] }));
+	};
+
+	socket.onmessage = function (event) {
+		var message = JSON.parse(event.data);
+
+		if (message.command !== "reload") {
+			return;
+		}
+
+		if (message.liveCSS) {
+			reloadStylesheet(message.path);
+		} else if (message.liveImg) {
+			reloadImages(message.path);
+		} else {
+			window.location.reload();
+		}
+	};
+
+	function reloadStylesheet(path) {
+		var links = document.getElementsByTagName("link");
+
+		for (var i = 0; i < links.length; i++) {
+			var link = links[i];
+
+			if (link.rel === "stylesheet" && link.href.indexOf(path.split("/").pop()) !== -1) {
+				var href = link.href.split("?")[0];
+				link.href = href + "?livereload=" + Date.now();
+			}
+		}
+	}
+
+	function reloadImages(path) {
+		var images = document.images;
+		var fileName = path.split("/").pop();
+
+		for (var i = 0; i < images.length; i++) {
+			var image = images[i];
+
+			if (image.src.indexOf(fileName) !== -1) {
+				var src = image.src.split("?")[0];
+				image.src = src + "?livereload=" + Date.now();
+			}
+		}
+	}
+})();
+`
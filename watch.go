@@ -1,29 +1,32 @@
 package appix
 
 import (
+	"context"
 	"log"
-	"path"
 	"path/filepath"
 	"time"
 
+	"github.com/Travix-International/appix/appcatalog"
 	"github.com/Travix-International/appix/appixLogger"
 	"github.com/Travix-International/appix/config"
-	"github.com/rjeczalik/notify"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/Travix-International/appix/livereload"
+	"github.com/Travix-International/appix/watcher"
 )
 
-// This watcher implements a simple state machine, making sure we handle currently if change events come in while we are executing a push.
+// This watcher implements a simple state machine per app, making sure we handle currently if
+// change events come in while we are executing a push for that app.
 //
 // NOTE: The file watcher libraries sometimes send two separate events for one file change in quick succession. (Also, some editors, like vim, are doing multiple genuine file modifications for one single file save.)
 // To mitigate this we initially wait for a short while befor starting the push, to make sure we are not pushing twice for a single change. That's why we have the initialDelay state.
+// While we're in the initialDelay state, every event that keeps arriving resets the debounce timer, but never past coalesceWindow after the very first event of the batch, so a steady stream of saves can't delay the push forever.
 //
 //                              file change event
 // initial state                     received
 //   -------------> WAITING ------------------------> INITIAL_DELAY
 //                     Λ                                    |
-//                     |                                    | 100ms passed, executing push
+//                     |                                    | debounce passed (or coalesceWindow exceeded), executing push
 //                     |                                    |
 //                     |          push completed            V
 //                      -------------------------------- PUSHING
@@ -41,42 +44,87 @@ const (
 )
 
 var (
-	appPath      string
-	noBrowser    bool
-	timeout      int
-	watcherState = waiting
+	noBrowser      bool
+	timeout        int
+	debounce       time.Duration
+	coalesceWindow time.Duration
+	events         string
+	livereloadPort int
+	watcherBackend string
+	noCache        bool
+	ignoreDirs     bool
+	ignoreSymlinks bool
 )
 
 // RegisterWatch registers the 'watch' command.
 func RegisterWatch(app *kingpin.Application, config config.Config, args *GlobalArgs, logger *appixLogger.Logger) {
 	var localFrontend bool
+	var appPaths []string
 
 	command := app.Command("watch", "Watches the current directory for changes, and pushes on any change.").
 		Action(func(parseContext *kingpin.ParseContext) error {
-			// Channel on which we get file change events.
-			fileWatch := make(chan notify.EventInfo)
-			// Channel on which we get an event when the initial short delay after a change is passed.
-			initialDelayDone := make(chan int)
-			// Channel on which we get events when the pushes are done.
-			pushDone := make(chan int)
-
-			// NOTE: We need to convert to absolute path, because the file watcher wouldn't accept relative paths on Windows.
-			absPath, err := filepath.Abs(appPath)
+			roots, err := resolveAppRoots(appPaths)
 
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			if err := notify.Watch(path.Join(absPath, "..."), fileWatch, notify.All); err != nil {
+			apps := make(map[string]*appState, len(roots))
+
+			for _, root := range roots {
+				apps[root] = &appState{appPath: root, state: waiting}
+			}
+
+			selectedEvents, err := parseEventClasses(events)
+
+			if err != nil {
 				log.Fatal(err)
 			}
 
-			defer notify.Stop(fileWatch)
+			backend, ok := watcher.Backends[watcherBackend]
+
+			if !ok {
+				log.Fatalf("Unknown watcher backend %q\n", watcherBackend)
+			}
+
+			// Channel on which we get file change events, for every app.
+			fileWatch := make(chan watcher.Event)
+			// Channel on which we get notified once an app's initial short delay after a
+			// change has passed.
+			initialDelayDone := make(chan initialDelayTick)
+			// Channel on which we get the root of an app whose push has finished.
+			pushDone := make(chan string)
+
+			var stopFuncs []func()
+
+			for root := range apps {
+				stop, err := backend.Watch(root, selectedEvents, fileWatch)
+
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				stopFuncs = append(stopFuncs, stop)
+			}
+
+			defer func() {
+				for _, stop := range stopFuncs {
+					stop()
+				}
+			}()
+
+			appcatalog.SetCacheEnabled(!noCache)
 
+			livereload.SetPort(livereloadPort)
 			livereload.StartServer()
 
-			// Immediately push once, and then start watching.
-			doPush(config, args, true, localFrontend, nil, logger)
+			// Immediately push every app once, and then start watching. This call runs
+			// synchronously on the goroutine that's about to enter the select loop below, so
+			// pushDone must be nil here: doPush's pushDone <- appPath would otherwise block
+			// forever waiting for a read that can't happen until this loop starts.
+			for root := range apps {
+				doPush(context.Background(), config, args, root, true, localFrontend, nil, nil, logger)
+			}
 
 			livereload.SendReload()
 
@@ -84,56 +132,108 @@ func RegisterWatch(app *kingpin.Application, config config.Config, args *GlobalA
 			for {
 				select {
 				case ei := <-fileWatch:
+					owner := findOwningApp(apps, ei.Path)
+
+					if owner == nil {
+						break
+					}
+
+					isDir, isFile, isSymlink := classifyChange(ei)
+
 					if args.Verbose {
-						log.Println("File change event details:", ei)
+						log.Printf("File change event details: %+v (dir: %v, file: %v, symlink: %v)\n", ei, isDir, isFile, isSymlink)
+					}
+
+					if (ignoreDirs && isDir) || (ignoreSymlinks && isSymlink) {
+						if args.Verbose {
+							log.Println("Ignoring directory/symlink change:", ei.Path)
+						}
+						break
 					}
 
-					filePath := ei.Path()
-					relPath, err := filepath.Rel(absPath, filePath)
+					relPath, err := filepath.Rel(owner.appPath, ei.Path)
 
 					if err != nil {
-						log.Printf("Error obtaining relative file path to %s\n", filePath)
+						log.Printf("Error obtaining relative file path to %s\n", ei.Path)
 						break
 					}
 
-					if ignored := IgnoreFilePath(relPath); ignored {
+					if ignored := IgnoreFilePath(owner.appPath, relPath); ignored {
 						if args.Verbose {
-							log.Println("Ignoring file changes:", filePath)
+							log.Println("Ignoring file changes:", ei.Path)
 						}
 						break
 					}
 
-					if watcherState == waiting {
-						watcherState = initialDelay
+					owner.changedPaths = appendUniquePath(owner.changedPaths, ei.Path)
+
+					if owner.state == waiting {
+						owner.state = initialDelay
+						owner.batchDeadline = time.Now().Add(coalesceWindow)
+						scheduleInitialDelayDone(owner, initialDelayDone, debounce)
+					} else if owner.state == initialDelay {
+						scheduleInitialDelayDone(owner, initialDelayDone, debounce)
+					} else if owner.state == pushing {
+						owner.state = pushingAndGotEvent
+
+						// Abort the in-flight push rather than waiting for it to finish, so the
+						// fresher batch of changes goes out sooner.
+						if owner.cancel != nil {
+							owner.cancel()
+						}
+					}
+				case tick := <-initialDelayDone:
+					owner := apps[tick.root]
+
+					// A timer scheduled before the latest reset may still fire; ignore it if
+					// we already moved on, or if a later reset has superseded it.
+					if owner == nil || owner.state != initialDelay || owner.generation != tick.generation {
+						break
+					}
+
+					root := tick.root
+					owner.state = pushing
+
+					batch := owner.changedPaths
+					owner.changedPaths = nil
+
+					log.Printf("File change detected in %s, executing appix push.\n", root)
+
+					ctx, cancel := context.WithCancel(context.Background())
+					owner.cancel = cancel
+
+					go doPush(ctx, config, args, root, false, localFrontend, batch, pushDone, logger)
+				case root := <-pushDone:
+					owner := apps[root]
 
-						time.AfterFunc(100*time.Millisecond, func() {
-							initialDelayDone <- 0
-						})
-					} else if watcherState == pushing {
-						watcherState = pushingAndGotEvent
+					if owner == nil {
+						break
 					}
-				case <-initialDelayDone:
-					watcherState = pushing
 
-					log.Println("File change detected, executing appix push.")
+					owner.cancel = nil
 
-					go doPush(config, args, false, localFrontend, pushDone, logger)
-				case <-pushDone:
-					if watcherState == pushingAndGotEvent {
+					if owner.state == pushingAndGotEvent {
 						// A change event arrived while the previous push was happening, we push again.
-						watcherState = pushing
-						go doPush(config, args, false, localFrontend, pushDone, logger)
+						owner.state = pushing
+
+						batch := owner.changedPaths
+						owner.changedPaths = nil
+
+						ctx, cancel := context.WithCancel(context.Background())
+						owner.cancel = cancel
+
+						go doPush(ctx, config, args, root, false, localFrontend, batch, pushDone, logger)
 					} else {
-						watcherState = waiting
-						log.Println("Push done, watching for file changes.")
+						owner.state = waiting
+						log.Printf("Push done for %s, watching for file changes.\n", root)
 					}
 				}
 			}
 		})
 
-	command.Arg("appPath", "path to the App folder (default: current folder)").
+	command.Arg("appPaths", "path(s) to the App folder(s), or a parent directory containing several apps (default: current folder)").
 		Default(".").
-		ExistingDirVar(&appPath)
+		StringsVar(&appPaths)
 
 	command.Flag("noBrowser", "Appix won't open the frontend in the browser after every push.").
 		Default("false").
@@ -145,16 +245,97 @@ func RegisterWatch(app *kingpin.Application, config config.Config, args *GlobalA
 	command.Flag("timeout", "Set the maximum timeout for the request").
 		Default("10").
 		IntVar(&timeout)
+
+	command.Flag("debounce", "Time to wait after the last file change event before executing a push.").
+		Default("100ms").
+		DurationVar(&debounce)
+
+	command.Flag("coalesceWindow", "Maximum time to keep batching file change events into a single push, even if new events keep resetting the debounce timer.").
+		Default("1s").
+		DurationVar(&coalesceWindow)
+
+	command.Flag("events", "Comma-separated list of event classes to watch for: create, write, rename, remove. Defaults to all of them.").
+		StringVar(&events)
+
+	command.Flag("livereloadPort", "Port the LiveReload server listens on.").
+		Default("35729").
+		IntVar(&livereloadPort)
+
+	command.Flag("watcher", "File watcher backend to use: notify or fsnotify. Try fsnotify if notify's recursive watch misbehaves on your platform.").
+		Default("notify").
+		EnumVar(&watcherBackend, "notify", "fsnotify")
+
+	command.Flag("no-cache", "Always upload, even if the app hasn't changed since the last push.").
+		Default("false").
+		BoolVar(&noCache)
+
+	command.Flag("ignoreDirs", "Don't trigger a push for a change that only touches a directory, not a file (e.g. a bare mtime bump).").
+		Default("false").
+		BoolVar(&ignoreDirs)
+
+	command.Flag("ignoreSymlinks", "Don't trigger a push for a change to a symlink, as opposed to the file it points to.").
+		Default("false").
+		BoolVar(&ignoreSymlinks)
+}
+
+// initialDelayTick is sent once an app's debounce timer fires. generation is a snapshot of
+// owner.generation taken when the timer was armed, so the receiver can recognise a tick from a
+// timer that was superseded by a later reset as stale, even if Stop() lost the race to prevent
+// it firing.
+type initialDelayTick struct {
+	root       string
+	generation int
+}
+
+// scheduleInitialDelayDone (re)arms owner's debounce timer, stopping whichever one it had armed
+// before, so it fires once either debounce has passed since the most recent event, or
+// owner.batchDeadline is reached, whichever comes first. This is what makes every event in the
+// initialDelay state genuinely reset the debounce timer, rather than each event scheduling its
+// own independent timer alongside the others.
+func scheduleInitialDelayDone(owner *appState, ch chan<- initialDelayTick, debounce time.Duration) {
+	wait := debounce
+
+	if remaining := time.Until(owner.batchDeadline); remaining < wait {
+		wait = remaining
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+
+	if owner.debounceTimer != nil {
+		owner.debounceTimer.Stop()
+	}
+
+	owner.generation++
+	tick := initialDelayTick{root: owner.appPath, generation: owner.generation}
+
+	owner.debounceTimer = time.AfterFunc(wait, func() {
+		ch <- tick
+	})
+}
+
+// appendUniquePath appends path to paths, unless it's already present.
+func appendUniquePath(paths []string, path string) []string {
+	for _, existing := range paths {
+		if existing == path {
+			return paths
+		}
+	}
+
+	return append(paths, path)
 }
 
-func doPush(config config.Config, args *GlobalArgs, openBrowser bool, localFrontend bool, pushDone chan<- int, logger *appixLogger.Logger) {
-	push(config, appPath, !openBrowser, 180, timeout, localFrontend, args, logger)
+func doPush(ctx context.Context, config config.Config, args *GlobalArgs, appPath string, openBrowser bool, localFrontend bool, changedPaths []string, pushDone chan<- string, logger *appixLogger.Logger) {
+	push(ctx, config, appPath, !openBrowser, 180, timeout, localFrontend, args, logger)
 
-	if !openBrowser {
-		livereload.SendReload()
+	// Skip the reload if this push was cancelled; a fresh push for the same app with the
+	// latest changes will follow right behind it.
+	if !openBrowser && ctx.Err() == nil {
+		livereload.SendReloadForPaths(changedPaths)
 	}
 
 	if pushDone != nil {
-		pushDone <- 0
+		pushDone <- appPath
 	}
 }
@@ -0,0 +1,109 @@
+package appix
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// manifestFileName marks a directory as an app, as opposed to a parent directory containing
+// several apps.
+const manifestFileName = "app.yml"
+
+// appState tracks the push state machine for a single app being watched. Each app in a
+// multi-app watch gets its own, so a push for one app doesn't hold up another.
+type appState struct {
+	appPath string // absolute path to this app's root
+
+	state         int
+	changedPaths  []string
+	batchDeadline time.Time
+
+	// debounceTimer is the currently armed initialDelay timer for this app, if any. Each new
+	// event while in initialDelay stops and replaces it, so the debounce window genuinely
+	// resets instead of stacking up independent timers.
+	debounceTimer *time.Timer
+
+	// generation increments every time debounceTimer is (re)armed. A fired timer carries the
+	// generation it was armed with, so a timer that fires after being superseded - but before
+	// Stop() could prevent it - is recognised as stale and ignored.
+	generation int
+
+	// cancel aborts the push currently in flight for this app, if any. It lets a fresh
+	// change event interrupt a push instead of waiting for it to finish.
+	cancel context.CancelFunc
+}
+
+// resolveAppRoots expands paths into the absolute root directory of every app to watch. A
+// path that is itself an app (it has a manifestFileName) is used as-is. A path that isn't is
+// treated as a parent directory containing several apps, and every immediate subdirectory
+// with a manifest is added instead.
+func resolveAppRoots(paths []string) ([]string, error) {
+	var roots []string
+
+	for _, appPath := range paths {
+		absPath, err := filepath.Abs(appPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if isAppDir(absPath) {
+			roots = append(roots, absPath)
+			continue
+		}
+
+		entries, err := ioutil.ReadDir(absPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		foundApp := false
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			childPath := filepath.Join(absPath, entry.Name())
+
+			if isAppDir(childPath) {
+				roots = append(roots, childPath)
+				foundApp = true
+			}
+		}
+
+		if !foundApp {
+			return nil, fmt.Errorf("%s is not an app (no %s) and contains no app subdirectories", appPath, manifestFileName)
+		}
+	}
+
+	return roots, nil
+}
+
+func isAppDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, manifestFileName))
+	return err == nil
+}
+
+// findOwningApp returns the appState whose root is the longest matching prefix of path, so
+// events are routed to the right app even when one app's directory is nested under another's
+// parent.
+func findOwningApp(apps map[string]*appState, path string) *appState {
+	var owner *appState
+	var longestRoot string
+
+	for root, app := range apps {
+		if (path == root || strings.HasPrefix(path, root+string(os.PathSeparator))) && len(root) > len(longestRoot) {
+			owner = app
+			longestRoot = root
+		}
+	}
+
+	return owner
+}
@@ -0,0 +1,29 @@
+// +build darwin
+
+package appix
+
+import (
+	"github.com/Travix-International/appix/watcher"
+	"github.com/rjeczalik/notify"
+)
+
+// classifyChange inspects the underlying FSEvents flags to tell a directory change
+// apart from a file or symlink change, since FSEvents often reports a bare mtime bump
+// on a directory when one of its children changes. Only the notify backend exposes these
+// flags; when ev.Raw isn't a notify.EventInfo (e.g. the fsnotify backend is in use), it
+// falls back to treating the change as a plain file change.
+func classifyChange(ev watcher.Event) (isDir bool, isFile bool, isSymlink bool) {
+	ei, ok := ev.Raw.(notify.EventInfo)
+	if !ok {
+		return false, true, false
+	}
+
+	fsEvent, ok := ei.Sys().(*notify.FSEvent)
+	if !ok {
+		return false, true, false
+	}
+
+	flags := notify.Event(fsEvent.Flags)
+
+	return flags&notify.FSEventsIsDir != 0, flags&notify.FSEventsIsFile != 0, flags&notify.FSEventsIsSymlink != 0
+}
@@ -0,0 +1,27 @@
+// +build !darwin
+
+package appix
+
+import (
+	"os"
+
+	"github.com/Travix-International/appix/watcher"
+)
+
+// classifyChange falls back to an Lstat, since platforms other than darwin don't expose
+// the FSEvents flags that distinguish a directory, file, or symlink change up front.
+//
+// Caveat: for a Remove event the path is already gone by the time Lstat runs, so it falls
+// back to reporting isFile, even if the removed path was actually a directory or symlink.
+// --ignoreDirs/--ignoreSymlinks therefore can't suppress a directory or symlink removal on
+// these platforms, only creates/writes on a path that still exists.
+func classifyChange(ev watcher.Event) (isDir bool, isFile bool, isSymlink bool) {
+	info, err := os.Lstat(ev.Path)
+	if err != nil {
+		return false, true, false
+	}
+
+	mode := info.Mode()
+
+	return mode.IsDir(), mode.IsRegular(), mode&os.ModeSymlink != 0
+}
@@ -0,0 +1,87 @@
+package appix
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleInitialDelayDoneResetsOnNewEvent(t *testing.T) {
+	owner := &appState{batchDeadline: time.Now().Add(time.Second)}
+	ch := make(chan initialDelayTick, 10)
+
+	const debounce = 80 * time.Millisecond
+
+	start := time.Now()
+	scheduleInitialDelayDone(owner, ch, debounce)
+
+	// Reset partway through the first debounce window. If the reset genuinely replaces the
+	// timer, the tick should land roughly debounce after *this* call, not the first one.
+	time.Sleep(debounce / 2)
+	scheduleInitialDelayDone(owner, ch, debounce)
+	resetAt := time.Now()
+
+	select {
+	case tick := <-ch:
+		elapsedSinceReset := time.Since(resetAt)
+
+		if elapsedSinceReset < debounce/2 {
+			t.Fatalf("tick arrived %v after the reset, want at least %v (the reset did not take effect)", elapsedSinceReset, debounce/2)
+		}
+
+		if tick.root != owner.appPath {
+			t.Errorf("tick.root = %q, want %q", tick.root, owner.appPath)
+		}
+
+		if tick.generation != owner.generation {
+			t.Errorf("tick.generation = %d, want owner.generation = %d", tick.generation, owner.generation)
+		}
+	case <-time.After(2 * debounce):
+		t.Fatalf("no tick received within %v of starting at %v", 2*debounce, start)
+	}
+
+	// Only one tick should ever arrive for this batch: the first timer must have been stopped
+	// rather than left to fire independently.
+	select {
+	case tick := <-ch:
+		t.Fatalf("received an unexpected extra tick: %+v", tick)
+	case <-time.After(debounce):
+	}
+}
+
+func TestScheduleInitialDelayDoneCapsAtDeadline(t *testing.T) {
+	const debounce = time.Second
+
+	owner := &appState{batchDeadline: time.Now().Add(30 * time.Millisecond)}
+	ch := make(chan initialDelayTick, 1)
+
+	start := time.Now()
+	scheduleInitialDelayDone(owner, ch, debounce)
+
+	select {
+	case <-ch:
+		if elapsed := time.Since(start); elapsed >= debounce {
+			t.Fatalf("tick took %v, want well under the %v debounce (should have been capped by batchDeadline)", elapsed, debounce)
+		}
+	case <-time.After(debounce):
+		t.Fatal("no tick received, batchDeadline did not cap the wait")
+	}
+}
+
+func TestScheduleInitialDelayDoneGenerationIncrements(t *testing.T) {
+	owner := &appState{batchDeadline: time.Now().Add(time.Second)}
+	ch := make(chan initialDelayTick, 10)
+
+	// A debounce long enough that neither call's timer can fire before this test asserts on
+	// owner.generation.
+	const debounce = time.Hour
+
+	scheduleInitialDelayDone(owner, ch, debounce)
+	first := owner.generation
+
+	scheduleInitialDelayDone(owner, ch, debounce)
+	second := owner.generation
+
+	if second <= first {
+		t.Fatalf("owner.generation did not increase across resets: first=%d, second=%d", first, second)
+	}
+}
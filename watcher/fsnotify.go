@@ -0,0 +1,104 @@
+package watcher
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FsnotifyBackend wraps fsnotify/fsnotify, walking the tree to add a watch on every
+// subdirectory up front and re-adding watches on new subdirectories as they're created, as
+// the fsnotify examples show. It's a fallback for platforms where notify's recursive watch
+// (kqueue/inotify/FSEvents/ReadDirectoryChangesW under the hood) is flaky.
+type FsnotifyBackend struct{}
+
+// Watch walks root adding a watch on every directory, then adds a watch on every directory
+// created under it afterwards, sending matching events on out. Nothing is done here when a
+// directory is removed.
+func (FsnotifyBackend) Watch(root string, ops Op, out chan<- Event) (stop func(), err error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addRecursive(fsWatcher, root); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-fsWatcher.Events:
+				if !ok {
+					return
+				}
+
+				if event.Op&fsnotify.Create != 0 {
+					if info, statErr := os.Stat(event.Name); statErr == nil && info.IsDir() {
+						if err := addRecursive(fsWatcher, event.Name); err != nil {
+							log.Println("Failed to watch new directory", event.Name, err)
+						}
+					}
+				}
+
+				op, matched := fsnotifyOp(event.Op)
+
+				if !matched || ops&op == 0 {
+					continue
+				}
+
+				out <- Event{Path: event.Name, Op: op}
+			case watchErr, ok := <-fsWatcher.Errors:
+				if !ok {
+					return
+				}
+
+				log.Println("fsnotify watcher error:", watchErr)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	stop = func() {
+		close(done)
+		fsWatcher.Close()
+	}
+
+	return stop, nil
+}
+
+func addRecursive(fsWatcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return fsWatcher.Add(path)
+		}
+
+		return nil
+	})
+}
+
+func fsnotifyOp(op fsnotify.Op) (Op, bool) {
+	switch {
+	case op&fsnotify.Create != 0:
+		return Create, true
+	case op&fsnotify.Write != 0:
+		return Write, true
+	case op&fsnotify.Rename != 0:
+		return Rename, true
+	case op&fsnotify.Remove != 0:
+		return Remove, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"path"
+
+	"github.com/rjeczalik/notify"
+)
+
+// notifyOps maps our Op flags to the notify.Event they correspond to.
+var notifyOps = []struct {
+	op    Op
+	event notify.Event
+}{
+	{Create, notify.Create},
+	{Write, notify.Write},
+	{Rename, notify.Rename},
+	{Remove, notify.Remove},
+}
+
+// NotifyBackend is the default Backend, wrapping rjeczalik/notify's recursive watch
+// (the ".../..." syntax).
+type NotifyBackend struct{}
+
+// Watch subscribes one notify channel per requested Op, as the notify examples demonstrate,
+// and fans every matching event into out as a watcher.Event carrying the original
+// notify.EventInfo in Raw.
+func (NotifyBackend) Watch(root string, ops Op, out chan<- Event) (stop func(), err error) {
+	var notifyChans []chan notify.EventInfo
+
+	// notify's own recursive-watch syntax; see https://godoc.org/github.com/rjeczalik/notify.
+	recursiveRoot := path.Join(root, "...")
+
+	stop = func() {
+		for _, notifyChan := range notifyChans {
+			notify.Stop(notifyChan)
+		}
+	}
+
+	for _, mapping := range notifyOps {
+		if ops&mapping.op == 0 {
+			continue
+		}
+
+		notifyChan := make(chan notify.EventInfo)
+
+		if err := notify.Watch(recursiveRoot, notifyChan, mapping.event); err != nil {
+			stop()
+			return nil, err
+		}
+
+		notifyChans = append(notifyChans, notifyChan)
+
+		go forward(notifyChan, mapping.op, out)
+	}
+
+	return stop, nil
+}
+
+func forward(notifyChan chan notify.EventInfo, op Op, out chan<- Event) {
+	for ei := range notifyChan {
+		out <- Event{Path: ei.Path(), Op: op, Raw: ei}
+	}
+}
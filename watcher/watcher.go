@@ -0,0 +1,44 @@
+// Package watcher abstracts over the file watching library in use, so appix can fall back
+// to a different implementation on platforms where one library's recursive watch support
+// is flaky.
+package watcher
+
+// Op is the watcher-agnostic classification of a single file system change.
+type Op uint8
+
+// The set of change classes a Backend can report. They're bit flags so callers can
+// subscribe to any combination of them.
+const (
+	Create Op = 1 << iota
+	Write
+	Rename
+	Remove
+)
+
+// All is every Op a Backend knows how to report.
+const All = Create | Write | Rename | Remove
+
+// Event is a single filesystem change, reported by whichever Backend is in use. Raw holds
+// the backend-specific event that produced it (e.g. a notify.EventInfo), for callers that
+// need backend-specific detail such as the darwin FSEvents flags; it's nil when the active
+// backend doesn't expose one.
+type Event struct {
+	Path string
+	Op   Op
+	Raw  interface{}
+}
+
+// Backend is a pluggable file watching implementation. appix ships one wrapping
+// rjeczalik/notify (the default) and one wrapping fsnotify/fsnotify, selectable with
+// --watcher, for platforms where notify's recursive watch is flaky.
+type Backend interface {
+	// Watch starts watching root recursively for the given ops, sending matching events on
+	// out until the returned stop function is called.
+	Watch(root string, ops Op, out chan<- Event) (stop func(), err error)
+}
+
+// Backends maps the names accepted by --watcher to their Backend.
+var Backends = map[string]Backend{
+	"notify":   NotifyBackend{},
+	"fsnotify": FsnotifyBackend{},
+}